@@ -0,0 +1,272 @@
+package ristretto
+
+/*
+#include "ristretto.h"
+
+// ristretto_interrupt aborts any statement currently executing against
+// handle, analogous to sqlite3_interrupt. It is safe to call from any
+// goroutine while another goroutine is blocked inside ristretto_exec or
+// ristretto_query on the same handle.
+extern int ristretto_interrupt(void* handle);
+*/
+import "C"
+import (
+	"context"
+	"sync"
+)
+
+// callState tracks whether one context-bound call has actually begun
+// executing its statement, so that a ctx firing while the call is still
+// waiting on db.mutex does not interrupt whichever unrelated statement
+// happens to be holding the lock at that moment.
+//
+// callState guards started/cancelled with its own mutex rather than
+// db.mutex deliberately: db.interrupt is safe to call while another
+// goroutine holds db.mutex inside the C call it is meant to abort (that is
+// the whole point of ristretto_interrupt, mirroring sqlite3_interrupt), so
+// cancel must never block on db.mutex itself. A Tx holds db.mutex for its
+// entire lifetime, so a cancel that tried to acquire it from inside that
+// same Tx's ExecContext/QueryContext would deadlock forever.
+type callState struct {
+	mu        sync.Mutex
+	started   bool
+	cancelled bool
+}
+
+// tryStart claims the call. For DB.ExecContext/QueryContext the caller
+// holds db.mutex when it calls this, which is what makes "started" mean
+// "this call's own statement, not some other goroutine's" despite
+// callState's mutex being private. It returns false if cancel already ran
+// while this call was still waiting for db.mutex, in which case the caller
+// must skip the statement entirely rather than run it uninterrupted.
+func (cs *callState) tryStart() bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.cancelled {
+		return false
+	}
+	cs.started = true
+	return true
+}
+
+// cancel marks the call cancelled and, only if it had actually started,
+// interrupts it. It never touches db.mutex, so it cannot deadlock against
+// a caller (e.g. a Tx) that already holds it, and it can actually abort a
+// statement that is genuinely in flight instead of waiting for it to
+// finish on its own first.
+func (cs *callState) cancel(db *DB) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.started {
+		db.interrupt()
+	}
+	cs.cancelled = true
+}
+
+// ExecContext is Exec with cancellation: if ctx is done before the
+// statement finishes, ristretto_interrupt aborts it and ExecContext
+// returns ctx.Err(). A ctx that fires while this call is still waiting on
+// db.mutex for another statement to finish cancels this call outright
+// instead of interrupting that unrelated statement.
+func (db *DB) ExecContext(ctx context.Context, sql string, args ...interface{}) error {
+	query, err := bindStmtArgs(sql, args)
+	if err != nil {
+		return err
+	}
+
+	cs := &callState{}
+	errCh := make(chan error, 1)
+	go func() {
+		db.mutex.Lock()
+		if !cs.tryStart() {
+			db.mutex.Unlock()
+			errCh <- ctx.Err()
+			return
+		}
+		defer db.mutex.Unlock()
+		errCh <- db.execLocked(query)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		cs.cancel(db)
+		<-errCh
+		return ctx.Err()
+	}
+}
+
+// QueryContext is QueryTyped with cancellation: if ctx is done before the
+// query finishes, ristretto_interrupt aborts it and QueryContext returns
+// ctx.Err(). See ExecContext for how a ctx firing before this call's
+// statement has actually started is handled.
+func (db *DB) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	type outcome struct {
+		rows *Rows
+		err  error
+	}
+	cs := &callState{}
+	resultCh := make(chan outcome, 1)
+	go func() {
+		db.mutex.Lock()
+		if !cs.tryStart() {
+			db.mutex.Unlock()
+			resultCh <- outcome{nil, ctx.Err()}
+			return
+		}
+		rows, err := db.queryTypedLocked(sql, args)
+		db.mutex.Unlock()
+		resultCh <- outcome{rows, err}
+	}()
+
+	select {
+	case res := <-resultCh:
+		return res.rows, res.err
+	case <-ctx.Done():
+		cs.cancel(db)
+		<-resultCh
+		return nil, ctx.Err()
+	}
+}
+
+// interrupt asks the database to abort whatever statement is currently
+// executing. db.handle only ever transitions from a live pointer to nil
+// on Close, so calling this concurrently with a Close is the same
+// race callers already accept when mixing context cancellation with
+// shutdown in sqlite3-style APIs.
+func (db *DB) interrupt() {
+	C.ristretto_interrupt(db.handle)
+}
+
+// ExecContext is Tx.Exec with cancellation. A Tx already holds db.mutex
+// for its entire lifetime, so this call's statement always starts as soon
+// as it is scheduled; cancel must not (and does not) try to acquire
+// db.mutex itself, since that mutex is already held by this same Tx and
+// re-acquiring it would deadlock the goroutine that is supposed to release
+// it via Commit/Rollback.
+func (tx *Tx) ExecContext(ctx context.Context, sql string, args ...interface{}) error {
+	if tx.done {
+		return errTxDone
+	}
+	query, err := bindStmtArgs(sql, args)
+	if err != nil {
+		return err
+	}
+
+	cs := &callState{started: true}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tx.db.execLocked(query)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		cs.cancel(tx.db)
+		<-errCh
+		return ctx.Err()
+	}
+}
+
+// QueryContext is Tx.Query with cancellation.
+func (tx *Tx) QueryContext(ctx context.Context, sql string, args ...interface{}) ([]QueryResult, error) {
+	if tx.done {
+		return nil, errTxDone
+	}
+
+	type outcome struct {
+		results []QueryResult
+		err     error
+	}
+	cs := &callState{started: true}
+	resultCh := make(chan outcome, 1)
+	go func() {
+		rows, err := tx.db.queryTypedLocked(sql, args)
+		if err != nil {
+			resultCh <- outcome{nil, err}
+			return
+		}
+		results, err := queryResultsFromRows(rows)
+		resultCh <- outcome{results, err}
+	}()
+
+	select {
+	case res := <-resultCh:
+		return res.results, res.err
+	case <-ctx.Done():
+		cs.cancel(tx.db)
+		<-resultCh
+		return nil, ctx.Err()
+	}
+}
+
+// ExecContext is Stmt.Exec with cancellation.
+func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) error {
+	if s.closed {
+		return &RistrettoError{Code: Error, Message: "Statement is closed"}
+	}
+	query, err := bindStmtArgs(s.query, args)
+	if err != nil {
+		return err
+	}
+	if s.inTx {
+		cs := &callState{started: true}
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.db.execLocked(query)
+		}()
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			cs.cancel(s.db)
+			<-errCh
+			return ctx.Err()
+		}
+	}
+	return s.db.ExecContext(ctx, query)
+}
+
+// QueryContext is Stmt.Query with cancellation.
+func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) ([]QueryResult, error) {
+	if s.closed {
+		return nil, &RistrettoError{Code: Error, Message: "Statement is closed"}
+	}
+	query, err := bindStmtArgs(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if s.inTx {
+		type outcome struct {
+			results []QueryResult
+			err     error
+		}
+		cs := &callState{started: true}
+		resultCh := make(chan outcome, 1)
+		go func() {
+			rows, err := s.db.queryTypedLocked(query, nil)
+			if err != nil {
+				resultCh <- outcome{nil, err}
+				return
+			}
+			results, err := queryResultsFromRows(rows)
+			resultCh <- outcome{results, err}
+		}()
+		select {
+		case res := <-resultCh:
+			return res.results, res.err
+		case <-ctx.Done():
+			cs.cancel(s.db)
+			<-resultCh
+			return nil, ctx.Err()
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return queryResultsFromRows(rows)
+}
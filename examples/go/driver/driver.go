@@ -0,0 +1,372 @@
+// Package driver registers RistrettoDB with the standard library's
+// database/sql package.
+//
+// It wraps the existing ristretto.DB Go bindings (which in turn wrap
+// the ristretto_open/ristretto_exec/ristretto_query/ristretto_close cgo
+// entry points) so that callers can use the familiar sql.Open/Query/Exec
+// surface, hand a *sql.DB to an ORM (xorm, sqlx, GORM), and get Rows.Scan
+// into typed Go values instead of a map[string]string.
+//
+// Example usage:
+//
+//	db, err := sql.Open("ristretto", "mydb.db")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer db.Close()
+//
+//	rows, err := db.Query("SELECT id, name FROM users WHERE id = ?", 1)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		var id int64
+//		var name string
+//		if err := rows.Scan(&id, &name); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ristretto "ristrettodb-go-bindings"
+)
+
+func init() {
+	sql.Register("ristretto", &Driver{})
+}
+
+// Driver implements driver.Driver for RistrettoDB.
+type Driver struct{}
+
+// Open opens a new connection to the RistrettoDB file named by name.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	db, err := ristretto.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{db: db}, nil
+}
+
+// conn implements driver.Conn on top of a *ristretto.DB.
+type conn struct {
+	db *ristretto.DB
+}
+
+// Prepare returns a statement bound to this connection. RistrettoDB has no
+// separate prepare step at the cgo layer, so the query text is simply held
+// until Exec/Query substitutes its "?" placeholders.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close closes the underlying database handle.
+func (c *conn) Close() error {
+	return c.db.Close()
+}
+
+// Begin starts a transaction using the SQL-level BEGIN/COMMIT/ROLLBACK
+// statements, since RistrettoDB has no dedicated per-tx cgo handle.
+func (c *conn) Begin() (driver.Tx, error) {
+	if err := c.db.Exec("BEGIN"); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c}, nil
+}
+
+// BeginTx implements driver.ConnBeginTx so sql.DB.BeginTx's ctx cancels
+// the transaction: if ctx is done before Commit/Rollback is called, the
+// transaction is rolled back automatically.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	driverTx, err := c.Begin()
+	if err != nil {
+		return nil, err
+	}
+	t := driverTx.(*tx)
+
+	if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			if t.finish() {
+				_ = c.db.Exec("ROLLBACK")
+			}
+		}()
+	}
+
+	return t, nil
+}
+
+// tx implements driver.Tx.
+type tx struct {
+	conn *conn
+
+	mutex    sync.Mutex
+	finished bool
+}
+
+// finish claims the transaction for whichever of Commit/Rollback/ctx
+// cancellation reaches it first, returning false for everyone else.
+func (t *tx) finish() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.finished {
+		return false
+	}
+	t.finished = true
+	return true
+}
+
+func (t *tx) Commit() error {
+	if !t.finish() {
+		return sql.ErrTxDone
+	}
+	return t.conn.db.Exec("COMMIT")
+}
+
+func (t *tx) Rollback() error {
+	if !t.finish() {
+		return sql.ErrTxDone
+	}
+	return t.conn.db.Exec("ROLLBACK")
+}
+
+// stmt implements driver.Stmt, plus driver.StmtExecContext/StmtQueryContext,
+// by substituting bound arguments into the statement text and delegating to
+// the connection's Exec/Query (or their ctx-aware counterparts).
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1 because RistrettoDB does not report the number of
+// "?" placeholders ahead of time; database/sql skips the argument count
+// check in that case.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	query, err := bindArgs(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.conn.db.Exec(query); err != nil {
+		return nil, err
+	}
+	return &result{}, nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	query, err := bindArgs(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := s.conn.db.QueryTyped(query)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(typed), nil
+}
+
+// ExecContext implements driver.StmtExecContext. Without it, database/sql
+// falls back to plain Exec and ctx has no effect on this statement; with
+// it, sql.DB.ExecContext's ctx bounds the call via ristretto.DB.ExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	query, err := bindArgs(s.query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.conn.db.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+	return &result{}, nil
+}
+
+// QueryContext implements driver.StmtQueryContext. Without it, database/sql
+// falls back to plain Query and ctx has no effect on this statement; with
+// it, sql.DB.QueryContext's ctx bounds the call via ristretto.DB.QueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	query, err := bindArgs(s.query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	typed, err := s.conn.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(typed), nil
+}
+
+// namedValues strips the parameter names off args, since RistrettoDB binds
+// "?" placeholders positionally.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return values
+}
+
+// result implements driver.Result. RistrettoDB's Exec only reports a
+// Result code, not an affected-row count or a last-insert id, so both
+// accessors return zero.
+type result struct{}
+
+func (r *result) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+// rows implements driver.Rows on top of a *ristretto.Rows, converting each
+// column according to the RistrettoDB type ristretto.Rows.ColumnTypes
+// reports for it rather than sniffing the value's string representation.
+type rows struct {
+	typed *ristretto.Rows
+	// scanDest is reused across Next calls to avoid an allocation per row;
+	// its element types are fixed once, from typed.ColumnTypes(), on the
+	// first call.
+	scanDest []interface{}
+}
+
+func newRows(typed *ristretto.Rows) *rows {
+	return &rows{typed: typed}
+}
+
+func (r *rows) Columns() []string {
+	return r.typed.Columns()
+}
+
+func (r *rows) Close() error {
+	return r.typed.Close()
+}
+
+// Next fills dest with the next row's values, scanning each column into a
+// sql.NullXxx destination chosen by its RistrettoDB column type.
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.typed.Next() {
+		if err := r.typed.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	if r.scanDest == nil {
+		colTypes := r.typed.ColumnTypes()
+		r.scanDest = make([]interface{}, len(dest))
+		for i := range r.scanDest {
+			colType := ristretto.NULLABLE
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			switch colType {
+			case ristretto.INTEGER:
+				r.scanDest[i] = new(sql.NullInt64)
+			case ristretto.REAL:
+				r.scanDest[i] = new(sql.NullFloat64)
+			default:
+				r.scanDest[i] = new(sql.NullString)
+			}
+		}
+	}
+
+	if err := r.typed.Scan(r.scanDest...); err != nil {
+		return err
+	}
+	for i, d := range r.scanDest {
+		switch v := d.(type) {
+		case *sql.NullInt64:
+			dest[i] = nullValue(v.Valid, v.Int64)
+		case *sql.NullFloat64:
+			dest[i] = nullValue(v.Valid, v.Float64)
+		case *sql.NullString:
+			dest[i] = nullValue(v.Valid, v.String)
+		}
+	}
+	return nil
+}
+
+// nullValue returns nil for a NULL column and v otherwise, matching how
+// driver.Value represents SQL NULL.
+func nullValue(valid bool, v interface{}) driver.Value {
+	if !valid {
+		return nil
+	}
+	return v
+}
+
+// bindArgs substitutes "?" placeholders in query with args, in order,
+// quoting strings using RistrettoDB's single-quote escaping rule. The
+// placeholder scan itself is ristretto.SplitPlaceholders, shared with the
+// package's own Stmt so a "?" inside a quoted TEXT literal in the query
+// text is not miscounted as a placeholder here either.
+func bindArgs(query string, args []driver.Value) (string, error) {
+	segments := ristretto.SplitPlaceholders(query)
+	placeholders := len(segments) - 1
+	if placeholders != len(args) {
+		if len(args) > placeholders {
+			return "", fmt.Errorf("ristretto: too many arguments for placeholders in %q", query)
+		}
+		return "", fmt.Errorf("ristretto: not enough arguments for placeholders in %q", query)
+	}
+
+	var b strings.Builder
+	for i, seg := range segments {
+		b.WriteString(seg)
+		if i < placeholders {
+			formatted, err := formatArg(args[i])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(formatted)
+		}
+	}
+	return b.String(), nil
+}
+
+// formatArg formats one of driver.Value's legal underlying types (per
+// database/sql/driver.IsValue: nil, int64, float64, bool, []byte, string,
+// time.Time) as RistrettoDB SQL text. time.Time is stored the same way
+// rows.go's scanCell reads it back out of an INTEGER column: as a Unix
+// timestamp.
+func formatArg(v driver.Value) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	case string:
+		return "'" + strings.ReplaceAll(val, "'", "''") + "'", nil
+	case []byte:
+		return "'" + strings.ReplaceAll(string(val), "'", "''") + "'", nil
+	case time.Time:
+		return strconv.FormatInt(val.Unix(), 10), nil
+	default:
+		return "", fmt.Errorf("ristretto: unsupported argument type %T", v)
+	}
+}
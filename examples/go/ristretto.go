@@ -64,15 +64,40 @@ package ristretto
 #include <stdlib.h>
 #include <stdint.h>
 #include <stdbool.h>
+#include <string.h>
 #include "ristretto.h"
 
-// Callback function for query results
-extern void queryCallback(void* ctx, int n_cols, char** values, char** col_names);
+// ristretto_column_schema describes one column of a Table V2 table, as
+// reported by ristretto_table_get_schema.
+typedef struct {
+	char name[64];
+	int  type;   // matches the ColumnType constants below
+	int  size;   // declared TEXT(N) width, 0 for non-TEXT columns
+	int  offset; // byte offset of this column within a packed row
+} ristretto_column_schema;
+
+// ristretto_table_get_schema fills out[] with up to max column schemas and
+// returns the number of columns, or -1 on error.
+extern int ristretto_table_get_schema(void* handle, ristretto_column_schema* out, int max);
+
+// ristretto_table_row_size returns the fixed packed row width in bytes.
+extern int ristretto_table_row_size(void* handle);
+
+// ristretto_table_append_row appends one fixed-width, pre-packed row plus
+// its NULL bitmap to the table.
+extern int ristretto_table_append_row(void* handle, const void* row, const uint8_t* null_bitmap);
+
+// ristretto_table_append_rows appends n contiguously packed rows (and their
+// NULL bitmaps) in a single call, amortizing the cgo crossing across a
+// whole batch.
+extern int ristretto_table_append_rows(void* handle, const void* rows, const uint8_t* null_bitmaps, int n);
 */
 import "C"
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"runtime"
 	"sync"
 	"unsafe"
@@ -248,6 +273,13 @@ func (db *DB) Exec(sql string) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
+	return db.execLocked(sql)
+}
+
+// execLocked runs sql via ristretto_exec. Callers must hold db.mutex, which
+// lets Tx (whose lifetime spans multiple statements under one lock) share
+// it with the plain, self-locking Exec above.
+func (db *DB) execLocked(sql string) error {
 	if db.closed {
 		return &RistrettoError{Code: Error, Message: "Database is closed"}
 	}
@@ -267,71 +299,57 @@ func (db *DB) Exec(sql string) error {
 // QueryResult represents a single row from a query result
 type QueryResult map[string]string
 
-// Query executes a SQL query and returns results
+// Query executes a SQL query and returns results as the original
+// map[string]string-per-row shape, for compatibility with existing
+// callers. It is now implemented on top of QueryTyped/Rows; new code
+// should prefer QueryTyped, which preserves column types and NULL instead
+// of flattening everything into strings.
 func (db *DB) Query(sql string) ([]QueryResult, error) {
-	db.mutex.Lock()
-	defer db.mutex.Unlock()
-
-	if db.closed {
-		return nil, &RistrettoError{Code: Error, Message: "Database is closed"}
-	}
-
-	// Create a channel to collect results
-	results := make([]QueryResult, 0)
-	resultsChan := make(chan QueryResult, 100)
-	done := make(chan error, 1)
-
-	// Store the channels in the callback context
-	ctx := &queryContext{
-		resultsChan: resultsChan,
-		done:       done,
+	rows, err := db.QueryTyped(sql)
+	if err != nil {
+		return nil, err
 	}
+	return queryResultsFromRows(rows)
+}
 
-	// Convert context to unsafe.Pointer
-	ctxPtr := unsafe.Pointer(ctx)
-
-	cSQL := C.CString(sql)
-	defer C.free(unsafe.Pointer(cSQL))
-
-	// Execute query with callback
-	go func() {
-		result := Result(C.ristretto_query(db.handle, cSQL, 
-			(*[0]byte)(C.queryCallback), ctxPtr))
-		
-		if result != OK {
-			errorMsg := C.GoString(C.ristretto_error_string(C.int(result)))
-			done <- &RistrettoError{Code: result, Message: errorMsg}
-		} else {
-			done <- nil
+// queryResultsFromRows drains rows into the legacy []QueryResult shape.
+func queryResultsFromRows(rows *Rows) ([]QueryResult, error) {
+	defer rows.Close()
+
+	results := make([]QueryResult, 0, len(rows.raw))
+	for rows.Next() {
+		row := rows.raw[rows.pos-1]
+		result := make(QueryResult, len(rows.columns))
+		for i, col := range rows.columns {
+			if !row[i].IsNull {
+				result[col] = row[i].Value
+			}
 		}
-		close(resultsChan)
-	}()
-
-	// Collect results
-	for row := range resultsChan {
-		results = append(results, row)
-	}
-
-	// Wait for query completion and check for errors
-	if err := <-done; err != nil {
-		return nil, err
+		results = append(results, result)
 	}
-
 	return results, nil
 }
 
-// queryContext holds channels for collecting query results
-type queryContext struct {
-	resultsChan chan QueryResult
-	done       chan error
-}
-
 // Table represents a RistrettoDB Table V2 ultra-fast table
 type Table struct {
 	handle unsafe.Pointer
 	name   string
 	mutex  sync.Mutex
 	closed bool
+
+	// schema/rowSize are populated lazily, on the first AppendRow call or
+	// the first Append through an Appender, from ristretto_table_get_schema.
+	schema  []columnSchema
+	rowSize int
+}
+
+// columnSchema is the Go-side view of a Table V2 column, used to pack
+// Values into the fixed-width row format the C layer expects.
+type columnSchema struct {
+	Name   string
+	Type   ColumnType
+	Size   int
+	Offset int
 }
 
 // CreateTable creates a new ultra-fast table
@@ -414,7 +432,13 @@ func (t *Table) GetRowCount() int64 {
 	return int64(C.ristretto_table_get_row_count(t.handle))
 }
 
-// AppendRow appends a row to the table
+// AppendRow appends a row to the table. values must have one entry per
+// column, in schema order; each value is packed into the table's
+// fixed-width row format and passed to ristretto_table_append_row in a
+// single cgo call.
+//
+// For bulk ingestion, prefer NewAppender, which reuses one pinned C buffer
+// across many rows instead of allocating and crossing into C per row.
 func (t *Table) AppendRow(values []Value) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -423,56 +447,230 @@ func (t *Table) AppendRow(values []Value) error {
 		return &RistrettoError{Code: Error, Message: "Table is closed"}
 	}
 
-	// This is a simplified version - full implementation would require
-	// proper C struct marshaling for the append operation
-	fmt.Printf("Would append row with %d values to table '%s':\n", len(values), t.name)
-	for i, value := range values {
-		fmt.Printf("  Column %d: %s\n", i, value.String())
+	if err := t.ensureSchema(); err != nil {
+		return err
+	}
+
+	row, nullBitmap, err := packRow(t.schema, t.rowSize, values)
+	if err != nil {
+		return err
+	}
+
+	cRow := C.CBytes(row)
+	defer C.free(cRow)
+	cBitmap := C.CBytes(nullBitmap)
+	defer C.free(cBitmap)
+
+	result := Result(C.ristretto_table_append_row(t.handle, cRow, (*C.uint8_t)(cBitmap)))
+	if result != OK {
+		errorMsg := C.GoString(C.ristretto_error_string(C.int(result)))
+		return &RistrettoError{Code: result, Message: errorMsg}
 	}
 
-	// TODO: Implement actual C function call with proper struct marshaling
 	return nil
 }
 
-// Name returns the table name
-func (t *Table) Name() string {
-	return t.name
+// ensureSchema fetches and caches the table's column schema and packed row
+// size. Callers must hold t.mutex.
+func (t *Table) ensureSchema() error {
+	if t.schema != nil {
+		return nil
+	}
+
+	const maxColumns = 64
+	var raw [maxColumns]C.ristretto_column_schema
+
+	n := int(C.ristretto_table_get_schema(t.handle, &raw[0], C.int(maxColumns)))
+	if n < 0 {
+		return &RistrettoError{Code: Error, Message: fmt.Sprintf("Failed to get schema for table '%s'", t.name)}
+	}
+
+	schema := make([]columnSchema, n)
+	for i := 0; i < n; i++ {
+		schema[i] = columnSchema{
+			Name:   C.GoString(&raw[i].name[0]),
+			Type:   ColumnType(raw[i]._type),
+			Size:   int(raw[i].size),
+			Offset: int(raw[i].offset),
+		}
+	}
+
+	t.schema = schema
+	t.rowSize = int(C.ristretto_table_row_size(t.handle))
+	return nil
 }
 
-//export queryCallback
-func queryCallback(ctx unsafe.Pointer, nCols C.int, values **C.char, colNames **C.char) {
-	// Convert the context back to our struct
-	context := (*queryContext)(ctx)
+// packRow packs values into a fixed-width row buffer of rowSize bytes plus
+// a NULL bitmap (one bit per column), according to schema.
+func packRow(schema []columnSchema, rowSize int, values []Value) (row []byte, nullBitmap []byte, err error) {
+	if len(values) != len(schema) {
+		return nil, nil, fmt.Errorf("ristretto: expected %d values, got %d", len(schema), len(values))
+	}
 
-	// Convert C arrays to Go slices
-	valuesSlice := (*[1 << 28]*C.char)(unsafe.Pointer(values))[:nCols:nCols]
-	colNamesSlice := (*[1 << 28]*C.char)(unsafe.Pointer(colNames))[:nCols:nCols]
+	row = make([]byte, rowSize)
+	nullBitmap = make([]byte, (len(schema)+7)/8)
 
-	// Build the result row
-	row := make(QueryResult)
-	for i := 0; i < int(nCols); i++ {
-		var colName string
-		var value string
+	for i, col := range schema {
+		v := values[i]
+		if v.IsNull {
+			nullBitmap[i/8] |= 1 << uint(i%8)
+			continue
+		}
 
-		if colNamesSlice[i] != nil {
-			colName = C.GoString(colNamesSlice[i])
-		} else {
-			colName = fmt.Sprintf("col_%d", i)
+		switch col.Type {
+		case INTEGER:
+			iv, ok := v.Data.(int64)
+			if !ok {
+				return nil, nil, fmt.Errorf("ristretto: column %d (%s): expected INTEGER value, got %T", i, col.Name, v.Data)
+			}
+			binary.LittleEndian.PutUint64(row[col.Offset:], uint64(iv))
+		case REAL:
+			fv, ok := v.Data.(float64)
+			if !ok {
+				return nil, nil, fmt.Errorf("ristretto: column %d (%s): expected REAL value, got %T", i, col.Name, v.Data)
+			}
+			binary.LittleEndian.PutUint64(row[col.Offset:], math.Float64bits(fv))
+		case TEXT:
+			sv, ok := v.Data.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("ristretto: column %d (%s): expected TEXT value, got %T", i, col.Name, v.Data)
+			}
+			if len(sv) > col.Size {
+				return nil, nil, fmt.Errorf("ristretto: column %d (%s): TEXT value of %d bytes exceeds TEXT(%d)", i, col.Name, len(sv), col.Size)
+			}
+			copy(row[col.Offset:col.Offset+col.Size], sv)
+		default:
+			return nil, nil, fmt.Errorf("ristretto: column %d (%s): unsupported column type %s", i, col.Name, col.Type)
 		}
+	}
+
+	return row, nullBitmap, nil
+}
+
+// Appender batches rows into a single pinned C buffer and flushes them with
+// one cgo call to ristretto_table_append_rows, instead of paying a cgo
+// crossing per row the way AppendRow does. Use it for bulk ingestion, e.g.
+// high-frequency trading data or batch imports.
+//
+// An Appender is not safe for concurrent use.
+type Appender struct {
+	table *Table
+
+	schema      []columnSchema
+	rowSize     int
+	bitmapWidth int
+	capacity    int
+	count       int
+
+	rowBuf    unsafe.Pointer
+	bitmapBuf unsafe.Pointer
+}
+
+// defaultAppenderCapacity is the number of rows held in the Appender's
+// pinned C buffer before Append automatically flushes.
+const defaultAppenderCapacity = 4096
 
-		if valuesSlice[i] != nil {
-			value = C.GoString(valuesSlice[i])
-		} else {
-			value = ""
+// NewAppender creates an Appender for batched inserts into t. Each Append
+// packs its row into a Go buffer and then copies it into the pinned C
+// buffer, so every row is copied once; rows reach C only on Flush.
+func (t *Table) NewAppender() *Appender {
+	return &Appender{table: t, capacity: defaultAppenderCapacity}
+}
+
+// Append packs values and copies them into the Appender's pinned buffer,
+// flushing automatically when the buffer fills.
+func (a *Appender) Append(values []Value) error {
+	if err := a.ensureBuffers(); err != nil {
+		return err
+	}
+	if a.count == a.capacity {
+		if err := a.Flush(); err != nil {
+			return err
 		}
+	}
 
-		row[colName] = value
+	row, nullBitmap, err := packRow(a.schema, a.rowSize, values)
+	if err != nil {
+		return err
 	}
 
-	// Send the row to the results channel
-	select {
-	case context.resultsChan <- row:
-	default:
-		// Channel is full or closed, ignore
+	rowDst := (*[1 << 28]byte)(unsafe.Pointer(uintptr(a.rowBuf) + uintptr(a.count*a.rowSize)))[:a.rowSize:a.rowSize]
+	copy(rowDst, row)
+
+	bitmapDst := (*[1 << 28]byte)(unsafe.Pointer(uintptr(a.bitmapBuf) + uintptr(a.count*a.bitmapWidth)))[:a.bitmapWidth:a.bitmapWidth]
+	copy(bitmapDst, nullBitmap)
+
+	a.count++
+	return nil
+}
+
+// AppendBatch appends each row in rows via Append, stopping at the first
+// error.
+func (a *Appender) AppendBatch(rows [][]Value) error {
+	for i, row := range rows {
+		if err := a.Append(row); err != nil {
+			return fmt.Errorf("ristretto: AppendBatch row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Flush sends any buffered rows to the table in a single cgo call. On
+// failure the buffered rows are left in place so a caller can retry the
+// flush instead of silently losing them.
+func (a *Appender) Flush() error {
+	if a.count == 0 {
+		return nil
 	}
+
+	result := Result(C.ristretto_table_append_rows(a.table.handle, a.rowBuf, (*C.uint8_t)(a.bitmapBuf), C.int(a.count)))
+	if result != OK {
+		errorMsg := C.GoString(C.ristretto_error_string(C.int(result)))
+		return &RistrettoError{Code: result, Message: errorMsg}
+	}
+	a.count = 0
+	return nil
+}
+
+// ensureBuffers lazily fetches the table schema and allocates the pinned C
+// buffers that back this Appender.
+func (a *Appender) ensureBuffers() error {
+	if a.rowBuf != nil {
+		return nil
+	}
+
+	a.table.mutex.Lock()
+	err := a.table.ensureSchema()
+	schema, rowSize := a.table.schema, a.table.rowSize
+	a.table.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	a.schema = schema
+	a.rowSize = rowSize
+	a.bitmapWidth = (len(schema) + 7) / 8
+
+	a.rowBuf = C.malloc(C.size_t(a.capacity * a.rowSize))
+	a.bitmapBuf = C.malloc(C.size_t(a.capacity * a.bitmapWidth))
+	runtime.SetFinalizer(a, (*Appender).free)
+	return nil
+}
+
+// free releases the Appender's pinned C buffers.
+func (a *Appender) free() {
+	if a.rowBuf != nil {
+		C.free(a.rowBuf)
+		a.rowBuf = nil
+	}
+	if a.bitmapBuf != nil {
+		C.free(a.bitmapBuf)
+		a.bitmapBuf = nil
+	}
+	runtime.SetFinalizer(a, nil)
+}
+
+// Name returns the table name
+func (t *Table) Name() string {
+	return t.name
 }
\ No newline at end of file
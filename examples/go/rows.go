@@ -0,0 +1,295 @@
+package ristretto
+
+/*
+#include <stdlib.h>
+#include "ristretto.h"
+
+// ristretto_query_typed behaves like ristretto_query, but the callback also
+// receives each column's RistrettoDB type so results can be converted to a
+// typed Go value instead of always round-tripping through a string.
+extern int ristretto_query_typed(void* handle, const char* sql, void* callback, void* ctx);
+
+// Callback function for typed query results
+extern void typedQueryCallback(void* ctx, int n_cols, char** values, char** col_names, int* col_types);
+*/
+import "C"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// cell is one column's value from a typed query row, distinguishing a real
+// SQL NULL (IsNull) from the empty string.
+type cell struct {
+	Value  string
+	IsNull bool
+}
+
+// Rows is the typed result of DB.QueryTyped, modeled after database/sql.Rows
+// and the cznic/ql result iteration pattern: call Next to advance, then
+// Scan to read the current row into typed destinations.
+type Rows struct {
+	columns  []string
+	colTypes []ColumnType
+	raw      [][]cell
+	pos      int
+	err      error
+	closed   bool
+}
+
+// Columns returns the result set's column names, in order.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// ColumnTypes returns the RistrettoDB type of each column, in the same
+// order as Columns.
+func (r *Rows) ColumnTypes() []ColumnType {
+	return r.colTypes
+}
+
+// Next advances to the next row, returning false when there are no more
+// rows or the Rows has been closed.
+func (r *Rows) Next() bool {
+	if r.closed || r.pos >= len(r.raw) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// Err returns the first error encountered while iterating, if any.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close marks the Rows as exhausted. RistrettoDB's query callback has
+// already delivered every row by the time QueryTyped returns, so Close
+// only needs to stop further iteration.
+func (r *Rows) Close() error {
+	r.closed = true
+	return nil
+}
+
+// Scan copies the current row's columns into dest, in order. Supported
+// destination types are *int64, *float64, *string, *[]byte, *bool,
+// *time.Time (for INTEGER columns holding unix timestamps), and the
+// sql.NullXxx family for columns that may be NULL.
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.pos == 0 || r.pos > len(r.raw) {
+		return errors.New("ristretto: Scan called without a successful call to Next")
+	}
+	row := r.raw[r.pos-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("ristretto: Scan got %d destinations, row has %d columns", len(dest), len(row))
+	}
+
+	for i, d := range dest {
+		colType := NULLABLE
+		if i < len(r.colTypes) {
+			colType = r.colTypes[i]
+		}
+		if err := scanCell(row[i], colType, d); err != nil {
+			name := fmt.Sprintf("%d", i)
+			if i < len(r.columns) {
+				name = r.columns[i]
+			}
+			return fmt.Errorf("ristretto: Scan column %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// scanCell converts one cell into dest, based on dest's concrete type.
+func scanCell(c cell, colType ColumnType, dest interface{}) error {
+	switch d := dest.(type) {
+	case *sql.NullString:
+		d.Valid = !c.IsNull
+		d.String = c.Value
+		return nil
+	case *sql.NullInt64:
+		if c.IsNull {
+			d.Valid = false
+			return nil
+		}
+		v, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		d.Valid, d.Int64 = true, v
+		return nil
+	case *sql.NullFloat64:
+		if c.IsNull {
+			d.Valid = false
+			return nil
+		}
+		v, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return err
+		}
+		d.Valid, d.Float64 = true, v
+		return nil
+	case *sql.NullBool:
+		if c.IsNull {
+			d.Valid = false
+			return nil
+		}
+		d.Valid, d.Bool = true, c.Value != "0" && c.Value != ""
+		return nil
+	case *sql.NullTime:
+		if c.IsNull {
+			d.Valid = false
+			return nil
+		}
+		v, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		d.Valid, d.Time = true, time.Unix(v, 0)
+		return nil
+	}
+
+	if c.IsNull {
+		return fmt.Errorf("converting NULL to %T is unsupported, use a sql.NullXxx destination", dest)
+	}
+
+	switch d := dest.(type) {
+	case *int64:
+		v, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*d = v
+	case *float64:
+		v, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return err
+		}
+		*d = v
+	case *string:
+		*d = c.Value
+	case *[]byte:
+		*d = []byte(c.Value)
+	case *bool:
+		*d = c.Value != "0" && c.Value != ""
+	case *time.Time:
+		if colType != INTEGER {
+			return fmt.Errorf("cannot scan %s column into *time.Time", colType)
+		}
+		v, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*d = time.Unix(v, 0)
+	default:
+		return fmt.Errorf("unsupported Scan destination type %T", dest)
+	}
+	return nil
+}
+
+// typedQueryContext collects rows from typedQueryCallback into a Rows.
+type typedQueryContext struct {
+	columns  []string
+	colTypes []ColumnType
+	rowsChan chan []cell
+	done     chan error
+}
+
+// QueryTyped executes sql (with "?" placeholders bound to args, using the
+// same quoting rules as Stmt) and returns the result as a *Rows, which keeps
+// NULL distinct from the empty string and reports each column's RistrettoDB
+// type through ColumnTypes. Rows.Scan converts each value to the Go type of
+// its destination; the column type is checked only for *time.Time.
+//
+// The older Query method is kept for compatibility and is now implemented
+// in terms of QueryTyped.
+func (db *DB) QueryTyped(sql string, args ...interface{}) (*Rows, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.queryTypedLocked(sql, args)
+}
+
+// queryTypedLocked runs the typed query. Callers must hold db.mutex, which
+// lets Tx share it with the plain, self-locking QueryTyped above.
+func (db *DB) queryTypedLocked(sql string, args []interface{}) (*Rows, error) {
+	if db.closed {
+		return nil, &RistrettoError{Code: Error, Message: "Database is closed"}
+	}
+
+	query, err := bindStmtArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsChan := make(chan []cell, 100)
+	done := make(chan error, 1)
+	ctx := &typedQueryContext{rowsChan: rowsChan, done: done}
+	ctxPtr := unsafe.Pointer(ctx)
+
+	cSQL := C.CString(query)
+	defer C.free(unsafe.Pointer(cSQL))
+
+	go func() {
+		result := Result(C.ristretto_query_typed(db.handle, cSQL,
+			unsafe.Pointer(C.typedQueryCallback), ctxPtr))
+
+		if result != OK {
+			errorMsg := C.GoString(C.ristretto_error_string(C.int(result)))
+			done <- &RistrettoError{Code: result, Message: errorMsg}
+		} else {
+			done <- nil
+		}
+		close(rowsChan)
+	}()
+
+	rows := &Rows{}
+	for row := range rowsChan {
+		rows.columns, rows.colTypes = ctx.columns, ctx.colTypes
+		rows.raw = append(rows.raw, row)
+	}
+
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	rows.columns, rows.colTypes = ctx.columns, ctx.colTypes
+	return rows, nil
+}
+
+//export typedQueryCallback
+func typedQueryCallback(ctxPtr unsafe.Pointer, nCols C.int, values **C.char, colNames **C.char, colTypes *C.int) {
+	ctx := (*typedQueryContext)(ctxPtr)
+	n := int(nCols)
+
+	valuesSlice := (*[1 << 28]*C.char)(unsafe.Pointer(values))[:n:n]
+	colNamesSlice := (*[1 << 28]*C.char)(unsafe.Pointer(colNames))[:n:n]
+	colTypesSlice := (*[1 << 28]C.int)(unsafe.Pointer(colTypes))[:n:n]
+
+	if ctx.columns == nil {
+		ctx.columns = make([]string, n)
+		ctx.colTypes = make([]ColumnType, n)
+		for i := 0; i < n; i++ {
+			if colNamesSlice[i] != nil {
+				ctx.columns[i] = C.GoString(colNamesSlice[i])
+			} else {
+				ctx.columns[i] = fmt.Sprintf("col_%d", i)
+			}
+			ctx.colTypes[i] = ColumnType(colTypesSlice[i])
+		}
+	}
+
+	row := make([]cell, n)
+	for i := 0; i < n; i++ {
+		if valuesSlice[i] == nil {
+			row[i] = cell{IsNull: true}
+		} else {
+			row[i] = cell{Value: C.GoString(valuesSlice[i])}
+		}
+	}
+
+	ctx.rowsChan <- row
+}
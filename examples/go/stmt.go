@@ -0,0 +1,203 @@
+package ristretto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stmt is a prepared statement created by DB.Prepare. The statement text
+// uses "?" placeholders, which Exec/Query substitute with quoted/escaped
+// arguments before handing the SQL off to ristretto_exec/ristretto_query_typed.
+//
+// RistrettoDB has no separate prepare step at the cgo layer, and Prepare
+// only stores the statement text: the placeholder positions are found again
+// on every Exec/Query, so repeated calls still re-scan the SQL. What a Stmt
+// replaces is the fmt.Sprintf pattern used throughout the examples, since
+// TEXT arguments are quoted and escaped here instead of by hand.
+type Stmt struct {
+	db     *DB
+	query  string
+	closed bool
+
+	// inTx is set by Tx.Prepare for statements bound to a transaction,
+	// whose caller already holds db.mutex for the transaction's lifetime;
+	// such statements must use the *Locked helpers instead of Exec/Query,
+	// which would otherwise deadlock trying to re-acquire it.
+	inTx bool
+}
+
+// Prepare parses sql, which may contain "?" placeholders, into a reusable
+// Stmt bound to db.
+func (db *DB) Prepare(sql string) (*Stmt, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.closed {
+		return nil, &RistrettoError{Code: Error, Message: "Database is closed"}
+	}
+
+	return &Stmt{db: db, query: sql}, nil
+}
+
+// Exec substitutes args into the statement's placeholders and executes it.
+func (s *Stmt) Exec(args ...interface{}) error {
+	if s.closed {
+		return &RistrettoError{Code: Error, Message: "Statement is closed"}
+	}
+
+	query, err := bindStmtArgs(s.query, args)
+	if err != nil {
+		return err
+	}
+	if s.inTx {
+		return s.db.execLocked(query)
+	}
+	return s.db.Exec(query)
+}
+
+// Query substitutes args into the statement's placeholders and runs it as
+// a query, returning the same []QueryResult shape as DB.Query.
+func (s *Stmt) Query(args ...interface{}) ([]QueryResult, error) {
+	if s.closed {
+		return nil, &RistrettoError{Code: Error, Message: "Statement is closed"}
+	}
+
+	query, err := bindStmtArgs(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if s.inTx {
+		rows, err := s.db.queryTypedLocked(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		return queryResultsFromRows(rows)
+	}
+	return s.db.Query(query)
+}
+
+// ExecMany runs Exec once per row of args, stopping at the first error.
+// It is a convenience for batch inserts that still pays one cgo crossing
+// per row; Table.NewAppender is the zero-copy alternative for the Table
+// V2 API.
+func (s *Stmt) ExecMany(rows [][]interface{}) error {
+	for i, row := range rows {
+		if err := s.Exec(row...); err != nil {
+			return fmt.Errorf("ristretto: ExecMany row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Close marks the statement as no longer usable. It exists for symmetry
+// with database/sql's Stmt.Close; RistrettoDB holds no server-side
+// resources for a prepared statement to release.
+func (s *Stmt) Close() error {
+	s.closed = true
+	return nil
+}
+
+// bindStmtArgs substitutes "?" placeholders in query with args, in order,
+// quoting/escaping TEXT arguments using RistrettoDB's quoting rule (double
+// up embedded single quotes) and formatting numeric arguments directly.
+func bindStmtArgs(query string, args []interface{}) (string, error) {
+	segments := SplitPlaceholders(query)
+	placeholders := len(segments) - 1
+	if placeholders != len(args) {
+		return "", placeholderCountError(query, placeholders, len(args))
+	}
+
+	var b strings.Builder
+	for i, seg := range segments {
+		b.WriteString(seg)
+		if i == placeholders {
+			break
+		}
+		formatted, err := formatStmtArg(args[i])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(formatted)
+	}
+	return b.String(), nil
+}
+
+// SplitPlaceholders splits query on its "?" placeholders, skipping any "?"
+// that falls inside a single-quoted string literal in the query text. A
+// doubled single quote, RistrettoDB's escape for an embedded quote inside
+// such a literal, still ends in the right in-string state since there is
+// no character between the two quotes where a "?" could appear. The result
+// always has one more element than there are placeholders: result[i] is
+// the text immediately before the i-th placeholder, and the last element
+// is the text after the final one.
+//
+// It is exported so the database/sql driver package can share this scan
+// instead of maintaining its own copy, since the SQL text itself is
+// scanned the same way regardless of whether the caller supplies
+// interface{} or driver.Value arguments.
+func SplitPlaceholders(query string) []string {
+	segments := make([]string, 0, strings.Count(query, "?")+1)
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			inString = !inString
+			b.WriteByte(c)
+		case c == '?' && !inString:
+			segments = append(segments, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(segments, b.String())
+}
+
+// placeholderCountError reports a mismatch between a query's placeholder
+// count and its argument count.
+func placeholderCountError(query string, placeholders, args int) error {
+	if args > placeholders {
+		return fmt.Errorf("ristretto: too many arguments for placeholders in %q", query)
+	}
+	return fmt.Errorf("ristretto: not enough arguments for placeholders in %q", query)
+}
+
+func formatStmtArg(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case string:
+		return quoteText(v)
+	default:
+		return "", fmt.Errorf("ristretto: unsupported argument type %T", arg)
+	}
+}
+
+// quoteText applies RistrettoDB's TEXT quoting rule: wrap in single quotes
+// and double up any embedded single quote. NUL bytes and non-UTF8 input are
+// rejected since RistrettoDB's TEXT storage cannot represent them.
+func quoteText(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("ristretto: TEXT argument is not valid UTF-8")
+	}
+	if strings.IndexByte(s, 0) >= 0 {
+		return "", fmt.Errorf("ristretto: TEXT argument contains a NUL byte")
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
@@ -0,0 +1,86 @@
+package ristretto
+
+// Tx is a transaction started by DB.Begin. It holds db.mutex for its
+// entire lifetime, so statements run through it see a consistent,
+// uninterrupted view without another goroutine's Exec/Query interleaving
+// mid-transaction; Commit and Rollback release the lock again.
+type Tx struct {
+	db   *DB
+	done bool
+}
+
+// Begin starts a transaction by issuing BEGIN and holding db.mutex until
+// the returned Tx is committed or rolled back.
+func (db *DB) Begin() (*Tx, error) {
+	db.mutex.Lock()
+
+	if db.closed {
+		db.mutex.Unlock()
+		return nil, &RistrettoError{Code: Error, Message: "Database is closed"}
+	}
+
+	if err := db.execLocked("BEGIN"); err != nil {
+		db.mutex.Unlock()
+		return nil, err
+	}
+
+	return &Tx{db: db}, nil
+}
+
+// Exec substitutes args into sql's placeholders and executes it within the
+// transaction.
+func (tx *Tx) Exec(sql string, args ...interface{}) error {
+	if tx.done {
+		return errTxDone
+	}
+	query, err := bindStmtArgs(sql, args)
+	if err != nil {
+		return err
+	}
+	return tx.db.execLocked(query)
+}
+
+// Query substitutes args into sql's placeholders and runs it as a query
+// within the transaction.
+func (tx *Tx) Query(sql string, args ...interface{}) ([]QueryResult, error) {
+	if tx.done {
+		return nil, errTxDone
+	}
+	rows, err := tx.db.queryTypedLocked(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return queryResultsFromRows(rows)
+}
+
+// Prepare parses sql into a Stmt bound to this transaction.
+func (tx *Tx) Prepare(sql string) (*Stmt, error) {
+	if tx.done {
+		return nil, errTxDone
+	}
+	return &Stmt{db: tx.db, query: sql, inTx: true}, nil
+}
+
+// Commit issues COMMIT and releases db.mutex.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return errTxDone
+	}
+	tx.done = true
+	defer tx.db.mutex.Unlock()
+	return tx.db.execLocked("COMMIT")
+}
+
+// Rollback issues ROLLBACK and releases db.mutex.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return errTxDone
+	}
+	tx.done = true
+	defer tx.db.mutex.Unlock()
+	return tx.db.execLocked("ROLLBACK")
+}
+
+// errTxDone is returned by Tx methods once the transaction has already
+// been committed or rolled back.
+var errTxDone = &RistrettoError{Code: Error, Message: "Transaction has already been committed or rolled back"}